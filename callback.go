@@ -0,0 +1,202 @@
+package xxl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+)
+
+//CallbackParam 单条任务执行结果,对应调度中心 /api/callback 接口数组中的一项
+type CallbackParam struct {
+	LogID       int64  `json:"logId"`
+	LogDateTime int64  `json:"logDateTim"`
+	HandleCode  int64  `json:"handleCode"`
+	HandleMsg   string `json:"handleMsg"`
+}
+
+//CallbackStore 本地回调持久化存储,用于保存因网络故障暂时无法投递的回调结果,
+//避免执行器重启后丢失已完成任务的状态,可通过 WithCallbackStore 替换为BoltDB等实现
+type CallbackStore interface {
+	//Save 保存当前尚未投递成功的全部回调
+	Save(items []*CallbackParam) error
+	//Load 加载上次未投递成功的回调,执行器启动时调用一次
+	Load() ([]*CallbackParam, error)
+	//Clear 清空已持久化的回调,投递成功后调用
+	Clear() error
+}
+
+const (
+	callbackBatchMax   = 50               //单次投递最多携带的回调条数
+	callbackFlushEvery = time.Second       //定时投递间隔
+	callbackRetryTimes = 3                 //单轮投递失败后的重试次数
+	callbackRetryBase  = time.Millisecond * 500 //重试退避基数,每次重试翻倍
+)
+
+//runCallback 回调投递协程:合并一段时间内到达的回调为数组批量投递,失败时指数退避重试,
+//重试仍失败则持久化到CallbackStore,等待下一轮与新回调一起重试。
+//executor.Run优雅关闭时会close(callbackStop),此协程据此对callbackCh做最后一次排空和投递,
+//再close(callbackDone)退出,避免进程退出时刚完成任务的回调还留在channel里没人处理
+func (e *executor) runCallback() {
+	defer close(e.callbackDone)
+
+	var pending []*CallbackParam
+	if e.callbackStore != nil {
+		items, err := e.callbackStore.Load()
+		if err != nil {
+			log.Println("加载本地回调记录失败:" + err.Error())
+		} else if len(items) > 0 {
+			log.Printf("加载到%d条未投递成功的回调,将重新投递", len(items))
+			pending = append(pending, items...)
+		}
+	}
+
+	ticker := time.NewTicker(callbackFlushEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case item := <-e.callbackCh:
+			pending = append(pending, item)
+			pending = e.drainCallbackCh(pending)
+		case <-ticker.C:
+		case <-e.callbackStop:
+			for len(e.callbackCh) > 0 {
+				pending = append(pending, <-e.callbackCh)
+			}
+			e.flushCallback(pending)
+			return
+		}
+
+		pending = e.flushCallback(pending)
+	}
+}
+
+//flushCallback 按每批最多callbackBatchMax条投递pending,全部成功则清空本地持久化记录并返回nil;
+//某一批失败则持久化尚未投递成功的部分以便下一轮与新到达的回调一起重试,并返回这部分
+func (e *executor) flushCallback(pending []*CallbackParam) []*CallbackParam {
+	if len(pending) == 0 {
+		return pending
+	}
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > callbackBatchMax {
+			n = callbackBatchMax
+		}
+		if !e.sendCallback(pending[:n]) {
+			if e.callbackStore != nil {
+				if err := e.callbackStore.Save(pending); err != nil {
+					log.Println("持久化回调记录失败:" + err.Error())
+				}
+			}
+			return pending
+		}
+		pending = pending[n:]
+	}
+	if e.callbackStore != nil {
+		if err := e.callbackStore.Clear(); err != nil {
+			log.Println("清理本地回调记录失败:" + err.Error())
+		}
+	}
+	return nil
+}
+
+//drainCallbackCh 非阻塞地把已到达channel的回调一并合入本批次,凑够callbackBatchMax或取完为止
+func (e *executor) drainCallbackCh(pending []*CallbackParam) []*CallbackParam {
+	for len(pending) < callbackBatchMax {
+		select {
+		case item := <-e.callbackCh:
+			pending = append(pending, item)
+		default:
+			return pending
+		}
+	}
+	return pending
+}
+
+//sendCallback 投递一批回调,失败按指数退避重试,重试耗尽仍失败返回false
+func (e *executor) sendCallback(items []*CallbackParam) bool {
+	backoff := callbackRetryBase
+	for attempt := 0; attempt <= callbackRetryTimes; attempt++ {
+		if e.postCallback(items) {
+			return true
+		}
+		if attempt < callbackRetryTimes {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	log.Printf("任务回调投递失败,重试%d次后放弃,共%d条", callbackRetryTimes, len(items))
+	return false
+}
+
+func (e *executor) postCallback(items []*CallbackParam) bool {
+	body, err := json.Marshal(items)
+	if err != nil {
+		log.Println("回调参数序列化失败:" + err.Error())
+		return false
+	}
+	result, err := e.post("/api/callback", string(body))
+	if err != nil {
+		log.Println("任务回调失败:" + err.Error())
+		return false
+	}
+	defer result.Body.Close()
+	respBody, err := ioutil.ReadAll(result.Body)
+	if err != nil {
+		log.Println("任务回调读取响应失败:" + err.Error())
+		return false
+	}
+	r := &res{}
+	if err := json.Unmarshal(respBody, r); err != nil {
+		log.Println("任务回调解析响应失败:" + err.Error())
+		return false
+	}
+	if r.Code != 200 {
+		log.Println("任务回调被拒绝:" + string(respBody))
+		return false
+	}
+	log.Printf("任务回调成功,共%d条", len(items))
+	return true
+}
+
+//FileCallbackStore 默认的文件存储回调实现,将尚未投递成功的回调整体序列化为json文件
+type FileCallbackStore struct {
+	path string
+}
+
+//NewFileCallbackStore 创建文件存储回调实现,path为存储文件路径
+func NewFileCallbackStore(path string) *FileCallbackStore {
+	return &FileCallbackStore{path: path}
+}
+
+func (s *FileCallbackStore) Save(items []*CallbackParam) error {
+	body, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, body, 0644)
+}
+
+func (s *FileCallbackStore) Load() ([]*CallbackParam, error) {
+	body, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var items []*CallbackParam
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *FileCallbackStore) Clear() error {
+	err := os.Remove(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -19,6 +19,8 @@ type Executor interface {
 	//初始化
 	Init(...Option)
 	RegTask(pattern string, task TaskFunc)
+	//LogHandler 自定义日志查询handler
+	LogHandler(logHandler LogHandler)
 	Run() error
 }
 
@@ -36,12 +38,20 @@ func newExecutor(opts ...Option) *executor {
 }
 
 type executor struct {
-	opts    Options
-	address string
-	hasReg  bool
-	regList *taskList //注册任务列表
-	runList *taskList //正在执行任务列表
-	mu      sync.RWMutex
+	opts          Options
+	address       string
+	regList       *taskList  //注册任务列表
+	runList       *taskList  //正在执行任务列表
+	queueList     *taskQueue //SERIAL_EXECUTION阻塞策略下的排队任务列表
+	mu            sync.RWMutex
+	logHandler    LogHandler //日志查询handler
+	registry      Registry   //注册中心
+	regCancel     context.CancelFunc
+	registryDone  chan struct{}        //keepRegistry协程已退出,Run()据此保证Deregister发生在最后一次心跳Register之后
+	callbackCh    chan *CallbackParam //任务回调队列
+	callbackStore CallbackStore       //未投递成功的任务回调本地持久化
+	callbackStop  chan struct{}       //通知runCallback做最后一次flush后退出
+	callbackDone  chan struct{}       //runCallback已完成最后一次flush并退出
 }
 
 func (e *executor) Init(opts ...Option) {
@@ -54,8 +64,54 @@ func (e *executor) Init(opts ...Option) {
 	e.runList = &taskList{
 		data: make(map[string]*Task),
 	}
+	queueLength := e.opts.TaskQueueLength
+	if queueLength <= 0 {
+		queueLength = 10
+	}
+	e.queueList = newTaskQueue(queueLength)
 	e.address = e.opts.ExecutorIp + ":" + e.opts.ExecutorPort
-	go e.registry()
+	if e.logHandler == nil {
+		logDir := e.opts.LogDir
+		if logDir == "" {
+			logDir = "./logs"
+		}
+		e.logHandler = NewFileLogHandler(logDir)
+	}
+	if e.registry == nil {
+		if e.opts.Registry != nil {
+			e.registry = e.opts.Registry
+		} else {
+			e.registry = newHTTPRegistry(e.opts)
+		}
+	}
+	var cxt context.Context
+	cxt, e.regCancel = context.WithCancel(context.Background())
+	e.registryDone = make(chan struct{})
+	go e.keepRegistry(cxt)
+
+	e.callbackCh = make(chan *CallbackParam, 1000)
+	e.callbackStore = e.opts.CallbackStore
+	e.callbackStop = make(chan struct{})
+	e.callbackDone = make(chan struct{})
+	go e.runCallback()
+}
+
+//keepRegistry 注册执行器并保持心跳/租约存活,直到ctx结束;
+//退出前close(registryDone),使Run()能确保最后一次心跳Register已经完成之后再调用Deregister,
+//避免心跳续约与摘除并发导致Deregister撤销的是一个已经过期的旧租约/会话
+func (e *executor) keepRegistry(cxt context.Context) {
+	defer close(e.registryDone)
+	if err := e.registry.Register(); err != nil {
+		log.Println("执行器注册失败:" + err.Error())
+	}
+	if err := e.registry.KeepAlive(cxt); err != nil {
+		log.Println("执行器注册保活失败:" + err.Error())
+	}
+}
+
+//LogHandler 设置自定义日志查询handler,不设置则使用默认的文件存储实现
+func (e *executor) LogHandler(logHandler LogHandler) {
+	e.logHandler = logHandler
 }
 
 func (e *executor) Run() (err error) {
@@ -72,15 +128,67 @@ func (e *executor) Run() (err error) {
 		Handler:      mux,
 	}
 	// 监听端口并提供服务
-	log.Println("Starting server at " + e.address)
-	go server.ListenAndServe()
-	quit := make(chan os.Signal)
+	listenErr := make(chan error, 1)
+	go func() {
+		log.Println("Starting server at " + e.address)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			listenErr <- err
+		}
+	}()
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGKILL, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	e.registryRemove()
+	select {
+	case err = <-listenErr:
+		log.Println("监听端口失败:" + err.Error())
+		return err
+	case <-quit:
+	}
+
+	//先从调度中心摘除,不再接受新的调度。必须等keepRegistry协程真正退出后才能Deregister,
+	//否则一次正在进行中的心跳Register可能在Deregister之后才写入新租约/会话,导致摘除未生效
+	e.regCancel()
+	<-e.registryDone
+	if err := e.registry.Deregister(); err != nil {
+		log.Println("执行器摘除失败:" + err.Error())
+	}
+
+	//再停止HTTP服务接受新请求,并等待运行中的任务结束
+	shutdownTimeout := e.opts.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = time.Second * 10
+	}
+	cxt, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(cxt); err != nil {
+		log.Println("关闭HTTP服务失败:" + err.Error())
+	}
+	e.drainRunList(cxt)
+
+	//运行中的任务已结束(超时则只是已被取消),通知runCallback做最后一次flush,使已入队的回调不会随进程退出而丢失
+	close(e.callbackStop)
+	select {
+	case <-e.callbackDone:
+	case <-cxt.Done():
+		log.Println("优雅关闭超时,回调队列可能未完全投递")
+	}
 	return nil
 }
 
+//drainRunList 等待运行中任务结束,超时后取消仍在运行的任务
+func (e *executor) drainRunList(cxt context.Context) {
+	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
+	for e.runList.Len() > 0 {
+		select {
+		case <-cxt.Done():
+			log.Println("优雅关闭超时,强制终止仍在运行的任务")
+			e.runList.CancelAll()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 //注册任务
 func (e *executor) RegTask(pattern string, task TaskFunc) {
 	var t = &Task{}
@@ -108,7 +216,8 @@ func (e *executor) runTask(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 	cxt := context.Background()
-	task := e.regList.Get(param.ExecutorHandler)
+	regTask := e.regList.Get(param.ExecutorHandler)
+	task := &Task{fn: regTask.fn} //每次调度使用独立的任务实例,避免并发/排队场景下互相覆盖
 	if param.ExecutorTimeout > 0 {
 		task.Ext, task.Cancel = context.WithTimeout(cxt, time.Duration(param.ExecutorTimeout)*time.Second)
 	} else {
@@ -118,27 +227,49 @@ func (e *executor) runTask(writer http.ResponseWriter, request *http.Request) {
 	task.Name = param.ExecutorHandler
 	task.Param = param
 
-	//阻塞策略处理
-	if e.runList.Exists(Int64ToStr(task.Id)) {
-		if param.ExecutorBlockStrategy == coverEarly { //覆盖之前调度
-			oldTask := e.runList.Get(Int64ToStr(task.Id))
+	//阻塞策略处理:SHARDING_BROADCAST广播任务由调度中心把同一个jobId同时发给每个节点,
+	//各节点各自独立执行,不应套用单节点视角的阻塞策略(排队/丢弃),行为等同于覆盖之前调度
+	key := Int64ToStr(task.Id)
+	if param.IsBroadcast() {
+		if oldTask := e.runList.Get(key); oldTask != nil {
+			oldTask.Cancel()
+			e.runList.Del(key)
+		}
+	} else if e.runList.Exists(key) {
+		switch param.ExecutorBlockStrategy {
+		case coverEarly: //覆盖之前调度
+			oldTask := e.runList.Get(key)
 			if oldTask != nil {
 				oldTask.Cancel()
-				e.runList.Del(Int64ToStr(task.Id))
+				e.runList.Del(key)
 			}
-		} else { //单机串行,丢弃后续调度 都进行阻塞
-			writer.Write(returnCall(param, 500, "There are tasks running"))
-			log.Println("任务[" + Int64ToStr(param.JobID) + "]已经在运行了:" + param.ExecutorHandler)
+		case discardLater: //丢弃后续调度
+			writer.Write(returnCall(param, 500, "There are tasks running, discarded"))
+			log.Println("任务[" + Int64ToStr(param.JobID) + "]已经在运行了,丢弃本次调度:" + param.ExecutorHandler)
+			return
+		default: //单机串行,放入队列排队等待执行
+			if !e.queueList.Push(key, task) {
+				writer.Write(returnCall(param, 500, "There are tasks running, queue is full, discarded"))
+				log.Println("任务[" + Int64ToStr(param.JobID) + "]排队队列已满,丢弃本次调度:" + param.ExecutorHandler)
+				return
+			}
+			log.Println("任务[" + Int64ToStr(param.JobID) + "]已经在运行了,加入队列排队:" + param.ExecutorHandler)
+			writer.Write(returnGeneral())
 			return
 		}
 	}
 
+	e.startTask(task)
+	log.Println("任务[" + Int64ToStr(param.JobID) + "]开始执行:" + param.ExecutorHandler)
+	writer.Write(returnGeneral())
+}
+
+//startTask 将任务放入运行列表并异步执行
+func (e *executor) startTask(task *Task) {
 	e.runList.Set(Int64ToStr(task.Id), task)
 	go task.Run(func(code int64, msg string) {
 		e.callback(task, code, msg)
 	})
-	log.Println("任务[" + Int64ToStr(param.JobID) + "]开始执行:" + param.ExecutorHandler)
-	writer.Write(returnGeneral())
 }
 
 //删除一个任务
@@ -162,87 +293,50 @@ func (e *executor) killTask(writer http.ResponseWriter, request *http.Request) {
 //任务日志
 func (e *executor) taskLog(writer http.ResponseWriter, request *http.Request) {
 	data, _ := ioutil.ReadAll(request.Body)
-	req := &logReq{}
+	req := &LogReq{}
 	_ = json.Unmarshal(data, &req)
-	writer.Write(returnLog(req, 200))
-}
-
-//注册执行器到调度中心
-func (e *executor) registry() {
-
-	t := time.NewTimer(time.Second * 0) //初始立即执行
-	defer t.Stop()
-	req := &Registry{
-		RegistryGroup: "EXECUTOR",
-		RegistryKey:   e.opts.RegistryKey,
-		RegistryValue: "http://" + e.address,
-	}
-	param, err := json.Marshal(req)
+	res, err := e.logHandler.Handle(req)
 	if err != nil {
-		log.Fatal("执行器注册信息解析失败:" + err.Error())
-	}
-	for {
-		<-t.C
-		t.Reset(time.Second * time.Duration(20)) //20秒心跳防止过期
-		func() {
-			result, err := e.post("/api/registry", string(param))
-			if err != nil {
-				log.Println("执行器注册失败1:" + err.Error())
-				return
-			}
-			defer result.Body.Close()
-			body, err := ioutil.ReadAll(result.Body)
-			if err != nil {
-				log.Println("执行器注册失败2:" + err.Error())
-				return
-			}
-			res := &res{}
-			_ = json.Unmarshal(body, &res)
-			if res.Code != 200 {
-				log.Println("执行器注册失败3:" + string(body))
-				return
-			}
-			if !e.hasReg {
-				log.Println("执行器注册成功:" + string(body))
-			}
-			e.hasReg = true
-		}()
-
+		writer.Write(returnCall(nil, 500, err.Error()))
+		log.Println("日志查询失败:" + err.Error())
+		return
 	}
+	body, _ := json.Marshal(res)
+	writer.Write(body)
 }
 
-//执行器注册摘除
-func (e *executor) registryRemove() {
-	t := time.NewTimer(time.Second * 0) //初始立即执行
-	defer t.Stop()
-	req := &Registry{
-		RegistryGroup: "EXECUTOR",
-		RegistryKey:   e.opts.RegistryKey,
-		RegistryValue: "http://" + e.address,
-	}
-	param, err := json.Marshal(req)
-	if err != nil {
-		log.Println("执行器摘除失败:" + err.Error())
+//回调任务列表,实际的调度中心投递由runCallback异步批量完成
+func (e *executor) callback(task *Task, code int64, msg string) {
+	key := Int64ToStr(task.Id)
+
+	//必须先把回调交给callbackCh、写入结束标记,再把任务从runList摘除:drainRunList据runList是否
+	//清空来判断"所有任务已完成"从而触发最后一次回调flush,一旦runList先清空,回调却还没入队,
+	//flush就可能抢在这条回调之前发生,导致任务结束状态在进程退出时丢失
+	if completer, ok := e.logHandler.(LogCompleter); ok {
+		if err := completer.MarkDone(task.Param.LogDateTime, task.Param.LogID); err != nil {
+			log.Println("日志结束标记写入失败:" + err.Error())
+		}
 	}
-	res, err := e.post("/api/registryRemove", string(param))
-	if err != nil {
-		log.Println("执行器摘除失败:" + err.Error())
+	e.callbackCh <- &CallbackParam{
+		LogID:       task.Param.LogID,
+		LogDateTime: task.Param.LogDateTime,
+		HandleCode:  code,
+		HandleMsg:   msg,
 	}
-	body, err := ioutil.ReadAll(res.Body)
-	log.Println("执行器摘除成功:" + string(body))
-	e.hasReg = false
-	_ = res.Body.Close()
-}
 
-//回调任务列表
-func (e *executor) callback(task *Task, code int64, msg string) {
-	res, err := e.post("/api/callback", string(returnCall(task.Param, code, msg)))
-	if err != nil {
-		log.Println(err)
+	//Del/Pop/startTask必须与runTask的"判断runList是否存在同key任务"互斥,
+	//否则任务结束与新触发的调度交错时,可能导致同一个jobId被并发启动两次
+	e.mu.Lock()
+	e.runList.Del(key)
+	//SERIAL_EXECUTION阻塞策略下,当前任务执行完成后唤醒队列中排队的下一个任务
+	next := e.queueList.Pop(key)
+	if next != nil {
+		e.startTask(next)
+	}
+	e.mu.Unlock()
+	if next != nil {
+		log.Println("任务[" + Int64ToStr(next.Id) + "]出队开始执行:" + next.Name)
 	}
-	body, err := ioutil.ReadAll(res.Body)
-	e.runList.Del(Int64ToStr(task.Id))
-	log.Println("任务回调成功:" + string(body))
 }
 
 //post
@@ -0,0 +1,118 @@
+package xxl
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+//ResolveExecutorIp 解析执行器对外可访问的IP,依次尝试:自定义ExecutorIpFunc、显式配置的ExecutorIp、
+//PreferredCIDR匹配的网卡、本机第一张可用网卡,最后回退为向调度中心拨号探测出口IP(适用于docker/k8s等
+//IP在容器重建后会变化的环境)
+func ResolveExecutorIp(opts Options) string {
+	if opts.ExecutorIpFunc != nil {
+		if ip := opts.ExecutorIpFunc(); ip != "" {
+			return ip
+		}
+	}
+	if opts.ExecutorIp != "" {
+		return opts.ExecutorIp
+	}
+	if opts.PreferredCIDR != "" {
+		if ip := ipInCIDR(opts.PreferredCIDR); ip != "" {
+			return ip
+		}
+	}
+	if ip := firstInterfaceIp(); ip != "" {
+		return ip
+	}
+	return outboundIp(opts.ServerAddr)
+}
+
+//ipInCIDR 返回匹配指定CIDR的第一个可用网卡IP
+func ipInCIDR(cidr string) string {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return ""
+	}
+	return rangeInterfaces(func(ip net.IP) bool {
+		return ipNet.Contains(ip)
+	})
+}
+
+//firstInterfaceIp 返回第一张可用网卡的IPv4地址
+func firstInterfaceIp() string {
+	return rangeInterfaces(func(ip net.IP) bool {
+		return true
+	})
+}
+
+func rangeInterfaces(match func(ip net.IP) bool) string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	for _, iface := range ifaces {
+		if !usableInterface(iface) {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ip := extractIPv4(addr)
+			if ip != nil && match(ip) {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
+
+//usableInterface 跳过未启用、回环及常见的容器虚拟网卡(docker0/veth*/br-)
+func usableInterface(iface net.Interface) bool {
+	if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+		return false
+	}
+	name := iface.Name
+	if name == "docker0" || strings.HasPrefix(name, "veth") || strings.HasPrefix(name, "br-") {
+		return false
+	}
+	return true
+}
+
+func extractIPv4(addr net.Addr) net.IP {
+	var ip net.IP
+	switch v := addr.(type) {
+	case *net.IPNet:
+		ip = v.IP
+	case *net.IPAddr:
+		ip = v.IP
+	}
+	if ip == nil {
+		return nil
+	}
+	return ip.To4()
+}
+
+//outboundIp 通过向调度中心拨号,读取系统路由选择的出口IP作为兜底方案
+func outboundIp(serverAddr string) string {
+	host := serverAddr
+	if u, err := url.Parse(serverAddr); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if !strings.Contains(host, ":") {
+		host += ":80"
+	}
+	conn, err := net.Dial("udp", host)
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	localAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return ""
+	}
+	return localAddr.IP.String()
+}
@@ -0,0 +1,55 @@
+package xxl
+
+//阻塞处理策略
+const (
+	serialExecution = "SERIAL_EXECUTION" //单机串行
+	discardLater     = "DISCARD_LATER"    //丢弃后续调度
+	coverEarly       = "COVER_EARLY"      //覆盖之前调度
+)
+
+//broadcastRouteStrategy 广播路由策略,调度中心会把同一个jobId同时下发给每一个在线节点
+const broadcastRouteStrategy = "SHARDING_BROADCAST"
+
+//RunReq 调度中心触发任务请求参数
+type RunReq struct {
+	JobID                 int64  `json:"jobId"`                 //任务ID
+	ExecutorHandler       string `json:"executorHandler"`       //任务标识
+	ExecutorParams        string `json:"executorParams"`        //任务参数
+	ExecutorBlockStrategy string `json:"executorBlockStrategy"` //阻塞处理策略
+	ExecutorTimeout       int64  `json:"executorTimeout"`       //任务超时时间,单位秒,大于零时生效
+	ExecutorRouteStrategy string `json:"executorRouteStrategy"` //路由策略,SHARDING_BROADCAST时为广播分片任务
+	LogID                 int64  `json:"logId"`                 //本次调度日志ID
+	LogDateTime           int64  `json:"logDateTime"`           //本次调度日志时间
+	GlueType              string `json:"glueType"`              //任务模式
+	GlueSource            string `json:"glueSource"`             //GLUE脚本代码
+	GlueUpdatetime        string `json:"glueUpdatetime"`        //GLUE脚本更新时间
+	BroadcastIndex        int64  `json:"broadcastIndex"`        //分片参数:当前分片
+	BroadcastTotal        int64  `json:"broadcastTotal"`        //分片参数:总分片数
+}
+
+//ShardIndex 当前执行器的分片序号,用于 ExecutorRouteStrategy=SHARDING_BROADCAST 广播任务按分片处理数据
+func (r *RunReq) ShardIndex() int64 {
+	return r.BroadcastIndex
+}
+
+//ShardTotal 分片总数,与 ShardIndex 搭配使用,例如 `if id%req.ShardTotal() == req.ShardIndex() {...}`
+func (r *RunReq) ShardTotal() int64 {
+	return r.BroadcastTotal
+}
+
+//IsBroadcast 是否为SHARDING_BROADCAST广播路由策略下发的任务
+func (r *RunReq) IsBroadcast() bool {
+	return r.ExecutorRouteStrategy == broadcastRouteStrategy
+}
+
+//killReq 终止任务请求参数
+type killReq struct {
+	JobID int64 `json:"jobId"`
+}
+
+//registryParam 调度中心HTTP注册/摘除请求参数
+type registryParam struct {
+	RegistryGroup string `json:"registryGroup"`
+	RegistryKey   string `json:"registryKey"`
+	RegistryValue string `json:"registryValue"`
+}
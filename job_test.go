@@ -0,0 +1,29 @@
+package xxl
+
+import "testing"
+
+//TestRunReq_Shard 验证SHARDING_BROADCAST广播任务可以通过ShardIndex/ShardTotal按 `id % total == index` 分摊数据
+func TestRunReq_Shard(t *testing.T) {
+	req := &RunReq{ExecutorRouteStrategy: broadcastRouteStrategy, BroadcastIndex: 1, BroadcastTotal: 3}
+	if !req.IsBroadcast() {
+		t.Fatalf("IsBroadcast() = false, want true for %s", broadcastRouteStrategy)
+	}
+
+	ids := []int64{1, 2, 3, 4, 5, 6}
+	var handled []int64
+	for _, id := range ids {
+		if id%req.ShardTotal() == req.ShardIndex() {
+			handled = append(handled, id)
+		}
+	}
+
+	want := []int64{1, 4}
+	if len(handled) != len(want) {
+		t.Fatalf("handled = %v, want %v", handled, want)
+	}
+	for i := range want {
+		if handled[i] != want[i] {
+			t.Fatalf("handled = %v, want %v", handled, want)
+		}
+	}
+}
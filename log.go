@@ -0,0 +1,137 @@
+package xxl
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//LogReq 调度中心查询任务日志请求参数
+type LogReq struct {
+	LogDateTim  int64 `json:"logDateTim"`  //本次调度日志时间
+	LogID       int64 `json:"logId"`       //本次调度日志ID
+	FromLineNum int    `json:"fromLineNum"` //日志开始行号,滚动加载
+}
+
+//LogRes 调度中心查询任务日志响应参数
+type LogRes struct {
+	Code    int64          `json:"code"`
+	Msg     string         `json:"msg"`
+	Content *LogResContent `json:"content,omitempty"`
+}
+
+//LogResContent 日志响应内容
+type LogResContent struct {
+	FromLineNum int    `json:"fromLineNum"` //本次请求,日志开始行数
+	ToLineNum   int    `json:"toLineNum"`   //本次请求,日志结束行号
+	LogContent  string `json:"logContent"`  //本次请求日志内容
+	IsEnd       bool   `json:"isEnd"`       //日志是否全部加载完
+}
+
+//LogHandler 任务日志查询接口,用户可自定义实现并通过 Executor.LogHandler 注册
+type LogHandler interface {
+	Handle(req *LogReq) (*LogRes, error)
+}
+
+//LogCompleter 可选接口,LogHandler实现此接口时,框架会在任务结束后调用MarkDone,
+//使Handle能够返回真实的IsEnd,让调度中心的滚动拉取在任务结束前持续轮询
+type LogCompleter interface {
+	MarkDone(logDateTime, logId int64) error
+}
+
+//WriteLog 向指定logId对应的日志文件写入一行日志,配合 FileLogHandler 使用
+func WriteLog(logDir string, logId int64, logDateTime int64, msg string) error {
+	path := logFilePath(logDir, logDateTime, logId)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(fmt.Sprintf("%s %s\n", time.Now().Format("2006-01-02 15:04:05.000"), msg))
+	return err
+}
+
+func logFilePath(logDir string, logDateTime int64, logId int64) string {
+	date := time.Unix(logDateTime/1000, 0).Format("2006-01-02")
+	return filepath.Join(logDir, date, Int64ToStr(logId)+".log")
+}
+
+//doneFilePath 任务结束标记文件路径,与日志文件同目录,由MarkDone在任务结束后创建
+func doneFilePath(logDir string, logDateTime int64, logId int64) string {
+	return logFilePath(logDir, logDateTime, logId) + ".done"
+}
+
+//FileLogHandler 默认的文件存储日志实现,按天分目录,按logId分文件,支持按行滚动拉取
+type FileLogHandler struct {
+	LogDir string //日志存放目录
+}
+
+//NewFileLogHandler 创建默认的文件日志处理器
+func NewFileLogHandler(logDir string) *FileLogHandler {
+	return &FileLogHandler{LogDir: logDir}
+}
+
+//Handle 读取指定logId的日志,从fromLineNum行开始返回,文件不存在视为日志暂未产生。
+//IsEnd以MarkDone写入的结束标记文件为准,任务未结束前调度中心会按isEnd=false持续轮询
+func (h *FileLogHandler) Handle(req *LogReq) (*LogRes, error) {
+	isEnd := h.isDone(req.LogDateTim, req.LogID)
+	path := logFilePath(h.LogDir, req.LogDateTim, req.LogID)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &LogRes{Code: 200, Content: &LogResContent{
+			FromLineNum: req.FromLineNum,
+			ToLineNum:   req.FromLineNum,
+			LogContent:  "",
+			IsEnd:       isEnd,
+		}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if lineNum >= req.FromLineNum {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	content := ""
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	return &LogRes{Code: 200, Content: &LogResContent{
+		FromLineNum: req.FromLineNum,
+		ToLineNum:   lineNum,
+		LogContent:  content,
+		IsEnd:       isEnd,
+	}}, nil
+}
+
+func (h *FileLogHandler) isDone(logDateTime, logId int64) bool {
+	_, err := os.Stat(doneFilePath(h.LogDir, logDateTime, logId))
+	return err == nil
+}
+
+//MarkDone 标记指定logId的任务已结束,实现LogCompleter供框架在任务完成后调用
+func (h *FileLogHandler) MarkDone(logDateTime, logId int64) error {
+	path := doneFilePath(h.LogDir, logDateTime, logId)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte{}, 0644)
+}
@@ -0,0 +1,126 @@
+package xxl
+
+import "time"
+
+//Options 执行器配置
+type Options struct {
+	ServerAddr      string        //调度中心地址
+	AccessToken     string        //请求令牌
+	Timeout         time.Duration //接口超时时间
+	ExecutorIp      string        //本地(执行器)IP
+	ExecutorPort    string        //本地(执行器)端口
+	RegistryKey     string        //执行器名称
+	LogDir          string        //日志存放目录
+	TaskQueueLength int           //SERIAL_EXECUTION阻塞策略下,单个任务的排队队列长度,超出部分按DISCARD_LATER处理
+	Registry        Registry      //自定义注册中心实现,不设置则使用xxl-job-admin默认的HTTP注册协议
+	ShutdownTimeout time.Duration //优雅关闭时等待运行中任务结束的最长时间,超时后强制取消
+	CallbackStore   CallbackStore //未能投递成功的任务回调本地持久化实现,不设置则不持久化,仅在进程内重试
+	PreferredCIDR   string        //ExecutorIp为空时,优先选取该网段内的网卡IP,如"192.168.1.0/24"
+	ExecutorIpFunc  func() string //自定义执行器IP解析函数,优先级最高,例如从容器的downward API读取POD_IP
+}
+
+//Option 设置参数的函数
+type Option func(o *Options)
+
+func newOptions(opts ...Option) Options {
+	opt := Options{}
+	for _, o := range opts {
+		o(&opt)
+	}
+	return opt
+}
+
+//WithServerAddr 设置调度中心地址
+func WithServerAddr(addr string) Option {
+	return func(o *Options) {
+		o.ServerAddr = addr
+	}
+}
+
+//WithAccessToken 设置请求令牌
+func WithAccessToken(accessToken string) Option {
+	return func(o *Options) {
+		o.AccessToken = accessToken
+	}
+}
+
+//WithTimeout 设置请求超时时间
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = timeout
+	}
+}
+
+//WithExecutorIp 设置执行器IP
+func WithExecutorIp(ip string) Option {
+	return func(o *Options) {
+		o.ExecutorIp = ip
+	}
+}
+
+//WithExecutorPort 设置执行器端口
+func WithExecutorPort(port string) Option {
+	return func(o *Options) {
+		o.ExecutorPort = port
+	}
+}
+
+//WithRegistryKey 设置执行器名称
+func WithRegistryKey(registryKey string) Option {
+	return func(o *Options) {
+		o.RegistryKey = registryKey
+	}
+}
+
+//WithLogDir 设置日志存放目录
+func WithLogDir(logDir string) Option {
+	return func(o *Options) {
+		o.LogDir = logDir
+	}
+}
+
+//WithTaskQueueLength 设置SERIAL_EXECUTION阻塞策略下的排队队列长度
+func WithTaskQueueLength(length int) Option {
+	return func(o *Options) {
+		o.TaskQueueLength = length
+	}
+}
+
+//WithRegistry 设置自定义注册中心实现,例如 NewEtcdRegistry/NewConsulRegistry,
+//不设置则使用xxl-job-admin默认的HTTP注册协议
+func WithRegistry(registry Registry) Option {
+	return func(o *Options) {
+		o.Registry = registry
+	}
+}
+
+//WithShutdownTimeout 设置优雅关闭时等待运行中任务结束的最长时间,超时后强制取消仍在运行的任务
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.ShutdownTimeout = timeout
+	}
+}
+
+//WithCallbackStore 设置任务回调本地持久化实现,例如 NewFileCallbackStore,
+//避免进程重启导致未投递成功的任务完成状态丢失
+func WithCallbackStore(store CallbackStore) Option {
+	return func(o *Options) {
+		o.CallbackStore = store
+	}
+}
+
+//WithPreferredCIDR 设置ExecutorIp为空时优先选取的网段,如"192.168.1.0/24",
+//用于多网卡环境下选出调度中心能够访问到的那一张网卡
+func WithPreferredCIDR(cidr string) Option {
+	return func(o *Options) {
+		o.PreferredCIDR = cidr
+	}
+}
+
+//WithExecutorIpFunc 设置自定义执行器IP解析函数,优先级高于ExecutorIp,
+//例如在k8s中读取downward API注入的POD_IP环境变量
+func WithExecutorIpFunc(f func() string) Option {
+	return func(o *Options) {
+		o.ExecutorIpFunc = f
+	}
+}
@@ -0,0 +1,15 @@
+package xxl
+
+import "context"
+
+//Registry 服务注册接口,执行器启动时注册自身地址,运行期间维持心跳/租约,关闭时摘除注册信息。
+//默认使用 httpRegistry(xxl-job-admin 的HTTP注册协议),可通过 WithRegistry 替换为 etcd/consul 等实现,
+//以便将执行器接入不以 xxl-job-admin 作为唯一注册中心的服务网格环境
+type Registry interface {
+	//Register 注册执行器地址
+	Register() error
+	//Deregister 摘除执行器地址
+	Deregister() error
+	//KeepAlive 保持注册信息存活,阻塞运行直到ctx结束或发生不可恢复的错误
+	KeepAlive(ctx context.Context) error
+}
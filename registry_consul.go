@@ -0,0 +1,95 @@
+package xxl
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+//ConsulRegistry 基于consul会话(session) TTL的注册实现:Register创建一个
+//Behavior=delete的会话并绑定KV,KeepAlive每隔半个TTL重新解析IP并重新写入KV(而不仅仅续期会话),
+//使注册值在容器IP变化后依然保持正确,会话续期由consul的session.RenewPeriodic负责维持存活;
+//Deregister主动销毁会话,使KV随会话一并被consul删除
+type ConsulRegistry struct {
+	client    *api.Client
+	opts      Options
+	ttl       time.Duration
+	sessionID string
+}
+
+//NewConsulRegistry 创建consul注册实现,addr为consul agent地址,如"127.0.0.1:8500",
+//opts.RegistryKey为注册KV的key,注册值根据opts动态解析为"http://ip:port"
+func NewConsulRegistry(addr string, opts Options, ttl time.Duration) (*ConsulRegistry, error) {
+	cfg := api.DefaultConfig()
+	cfg.Address = addr
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsulRegistry{client: client, opts: opts, ttl: ttl}, nil
+}
+
+func (r *ConsulRegistry) Register() error {
+	if r.sessionID == "" {
+		session := r.client.Session()
+		id, _, err := session.Create(&api.SessionEntry{
+			TTL:      r.ttl.String(),
+			Behavior: api.SessionBehaviorDelete,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		r.sessionID = id
+	}
+	value := "http://" + ResolveExecutorIp(r.opts) + ":" + r.opts.ExecutorPort
+	//Put的Session字段会被consul忽略(只认Key/Flags/Value),必须用Acquire才能把KV真正绑定到会话上,
+	//使会话因TTL过期或被Destroy时该KV随之被删除
+	acquired, _, err := r.client.KV().Acquire(&api.KVPair{
+		Key:     r.opts.RegistryKey,
+		Value:   []byte(value),
+		Session: r.sessionID,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return errors.New("xxl: consul key已被其他会话持有,注册失败")
+	}
+	return nil
+}
+
+//KeepAlive 由consul的RenewPeriodic负责会话续期防止过期,同时每隔半个TTL重新解析IP并重新写入KV,
+//直到ctx结束
+func (r *ConsulRegistry) KeepAlive(ctx context.Context) error {
+	done := make(chan struct{})
+	renewErr := make(chan error, 1)
+	go func() {
+		renewErr <- r.client.Session().RenewPeriodic(r.ttl.String(), r.sessionID, nil, done)
+	}()
+
+	interval := r.ttl / 2
+	if interval <= 0 {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			close(done)
+			return <-renewErr
+		case <-t.C:
+			if err := r.Register(); err != nil {
+				log.Println("consul心跳注册失败:" + err.Error())
+			}
+		}
+	}
+}
+
+func (r *ConsulRegistry) Deregister() error {
+	_, err := r.client.Session().Destroy(r.sessionID, nil)
+	return err
+}
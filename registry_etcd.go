@@ -0,0 +1,85 @@
+package xxl
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+//EtcdRegistry 基于etcd v3租约的注册实现:Register申请一个新租约并写入 key->value,
+//KeepAlive每隔半个TTL重新解析IP并Register一次(而非单纯续约),使注册值在容器IP变化后依然保持正确,
+//旧租约在新租约写入成功后被主动撤销;Deregister撤销租约使注册信息立即失效,而不必等待TTL过期。
+//leaseID由KeepAlive的心跳goroutine和Deregister并发访问,需mu保护
+type EtcdRegistry struct {
+	client *clientv3.Client
+	opts   Options
+	ttl    int64 //租约TTL,单位秒
+
+	mu      sync.Mutex
+	leaseID clientv3.LeaseID
+}
+
+//NewEtcdRegistry 创建etcd注册实现,opts.RegistryKey为注册键,注册值根据opts动态解析为"http://ip:port"
+func NewEtcdRegistry(endpoints []string, opts Options, ttl int64) (*EtcdRegistry, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: time.Second * 5,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdRegistry{client: client, opts: opts, ttl: ttl}, nil
+}
+
+func (r *EtcdRegistry) Register() error {
+	value := "http://" + ResolveExecutorIp(r.opts) + ":" + r.opts.ExecutorPort
+	lease, err := r.client.Grant(context.Background(), r.ttl)
+	if err != nil {
+		return err
+	}
+	if _, err := r.client.Put(context.Background(), r.opts.RegistryKey, value, clientv3.WithLease(lease.ID)); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	oldLeaseID := r.leaseID
+	r.leaseID = lease.ID
+	r.mu.Unlock()
+	if oldLeaseID != 0 {
+		//旧租约已被新写入覆盖,主动撤销避免在etcd中残留
+		if _, err := r.client.Revoke(context.Background(), oldLeaseID); err != nil {
+			log.Println("etcd撤销旧租约失败:" + err.Error())
+		}
+	}
+	return nil
+}
+
+//KeepAlive 每隔半个TTL重新解析IP并Register一次,直到ctx结束
+func (r *EtcdRegistry) KeepAlive(ctx context.Context) error {
+	interval := time.Duration(r.ttl) * time.Second / 2
+	if interval <= 0 {
+		interval = time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := r.Register(); err != nil {
+				log.Println("etcd心跳注册失败:" + err.Error())
+			}
+		}
+	}
+}
+
+func (r *EtcdRegistry) Deregister() error {
+	r.mu.Lock()
+	leaseID := r.leaseID
+	r.mu.Unlock()
+	_, err := r.client.Revoke(context.Background(), leaseID)
+	return err
+}
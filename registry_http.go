@@ -0,0 +1,120 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//httpRegistry 默认的注册实现,沿用xxl-job-admin的HTTP注册协议,每20秒心跳一次防止过期。
+//每次心跳都会重新解析执行器IP,使注册地址在容器IP发生变化后依然保持正确
+type httpRegistry struct {
+	opts Options
+
+	mu          sync.Mutex
+	lastAddress string //上一次成功注册使用的http://ip:port,用于Deregister
+	hasReg      bool
+}
+
+func newHTTPRegistry(opts Options) *httpRegistry {
+	return &httpRegistry{opts: opts}
+}
+
+func (r *httpRegistry) Register() error {
+	address := "http://" + ResolveExecutorIp(r.opts) + ":" + r.opts.ExecutorPort
+	body, err := r.call("/api/registry", address)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.lastAddress = address
+	r.mu.Unlock()
+	if !r.hasReg {
+		log.Println("执行器注册成功:" + body)
+	}
+	r.hasReg = true
+	return nil
+}
+
+//KeepAlive 每20秒重新解析IP并注册一次,直到ctx结束
+func (r *httpRegistry) KeepAlive(ctx context.Context) error {
+	t := time.NewTicker(time.Second * 20)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := r.Register(); err != nil {
+				log.Println("执行器心跳注册失败:" + err.Error())
+			}
+		}
+	}
+}
+
+func (r *httpRegistry) Deregister() error {
+	r.mu.Lock()
+	address := r.lastAddress
+	r.mu.Unlock()
+	if address == "" {
+		address = "http://" + ResolveExecutorIp(r.opts) + ":" + r.opts.ExecutorPort
+	}
+	body, err := r.call("/api/registryRemove", address)
+	if err != nil {
+		return err
+	}
+	r.hasReg = false
+	log.Println("执行器摘除成功:" + body)
+	return nil
+}
+
+func (r *httpRegistry) call(action, address string) (string, error) {
+	param := &registryParam{
+		RegistryGroup: "EXECUTOR",
+		RegistryKey:   r.opts.RegistryKey,
+		RegistryValue: address,
+	}
+	reqBody, err := json.Marshal(param)
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequest("POST", r.opts.ServerAddr+action, strings.NewReader(string(reqBody)))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	request.Header.Set("XXL-JOB-ACCESS-TOKEN", r.opts.AccessToken)
+	client := http.Client{Timeout: r.opts.Timeout}
+	result, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer result.Body.Close()
+	respBody, err := ioutil.ReadAll(result.Body)
+	if err != nil {
+		return "", err
+	}
+	res := &res{}
+	if err := json.Unmarshal(respBody, res); err != nil {
+		return "", err
+	}
+	if res.Code != 200 {
+		return "", &registryError{action: action, body: string(respBody)}
+	}
+	return string(respBody), nil
+}
+
+//registryError 注册中心返回非200业务码时的错误
+type registryError struct {
+	action string
+	body   string
+}
+
+func (e *registryError) Error() string {
+	return "执行器注册失败[" + e.action + "]:" + e.body
+}
@@ -0,0 +1,32 @@
+package xxl
+
+import "encoding/json"
+
+//res 调度中心通用响应结构
+type res struct {
+	Code int64       `json:"code"`
+	Msg  string      `json:"msg"`
+	Content interface{} `json:"content,omitempty"`
+}
+
+//returnGeneral 成功响应,不附带消息
+func returnGeneral() []byte {
+	r := &res{Code: 200}
+	body, _ := json.Marshal(r)
+	return body
+}
+
+//returnCall 带结果码和消息的响应,用于/run、/log请求出错时返回给调度中心
+func returnCall(req *RunReq, code int64, msg string) []byte {
+	r := &res{Code: code, Msg: msg}
+	body, _ := json.Marshal(r)
+	return body
+}
+
+//returnKill 终止任务响应
+func returnKill(req *killReq, code int64) []byte {
+	r := &res{Code: code}
+	body, _ := json.Marshal(r)
+	return body
+}
+
@@ -0,0 +1,114 @@
+package xxl
+
+import (
+	"context"
+	"sync"
+)
+
+//TaskFunc 任务执行函数,ExecutorRouteStrategy=SHARDING_BROADCAST 的广播任务可通过
+//param.ShardIndex()/param.ShardTotal() 判断当前分片,按 `id % total == index` 分摊数据
+type TaskFunc func(cxt context.Context, param *RunReq) (msg string)
+
+//CallbackFunc 任务执行完成回调函数
+type CallbackFunc func(code int64, msg string)
+
+//Task 任务
+type Task struct {
+	Id     int64
+	Name   string
+	Cancel context.CancelFunc
+	Ext    context.Context
+	Param  *RunReq
+	fn     TaskFunc
+}
+
+//Run 运行任务
+func (t *Task) Run(callback CallbackFunc) {
+	msg := t.fn(t.Ext, t.Param)
+	callback(200, msg)
+}
+
+//taskList 任务列表
+type taskList struct {
+	mu   sync.RWMutex
+	data map[string]*Task
+}
+
+func (t *taskList) Set(key string, task *Task) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.data[key] = task
+}
+
+func (t *taskList) Get(key string) *Task {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.data[key]
+}
+
+func (t *taskList) Del(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.data, key)
+}
+
+func (t *taskList) Exists(key string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.data[key]
+	return ok
+}
+
+//Len 当前任务数
+func (t *taskList) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.data)
+}
+
+//CancelAll 取消所有任务,用于优雅关闭超时后强制终止仍在运行的任务
+func (t *taskList) CancelAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, task := range t.data {
+		task.Cancel()
+	}
+}
+
+//taskQueue 阻塞策略为 SERIAL_EXECUTION 时,同一任务排队等待执行的有界FIFO队列
+type taskQueue struct {
+	mu     sync.Mutex
+	data   map[string][]*Task
+	maxLen int //队列最大长度,超出后按丢弃处理
+}
+
+func newTaskQueue(maxLen int) *taskQueue {
+	return &taskQueue{
+		data:   make(map[string][]*Task),
+		maxLen: maxLen,
+	}
+}
+
+//Push 入队,队列已满时返回false
+func (q *taskQueue) Push(key string, task *Task) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.data[key]) >= q.maxLen {
+		return false
+	}
+	q.data[key] = append(q.data[key], task)
+	return true
+}
+
+//Pop 出队,队列为空时返回nil
+func (q *taskQueue) Pop(key string) *Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := q.data[key]
+	if len(list) == 0 {
+		return nil
+	}
+	task := list[0]
+	q.data[key] = list[1:]
+	return task
+}
@@ -0,0 +1,8 @@
+package xxl
+
+import "strconv"
+
+//int64转字符串
+func Int64ToStr(i int64) string {
+	return strconv.FormatInt(i, 10)
+}